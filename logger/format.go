@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Entry describes a single request/response cycle, passed to a FormatterFn
+// once the request has been handled.
+type Entry struct {
+	Time         time.Time
+	Method       string
+	URL          string
+	Query        string
+	RemoteIP     string
+	Status       int
+	Size         int
+	Duration     time.Duration
+	User         string
+	Subject      string
+	TraceID      string
+	Body         string
+	ResponseBody string
+}
+
+// FormatterFn renders an Entry into the final log line.
+type FormatterFn func(e Entry) string
+
+// Format sets a custom formatter producing the final log line, replacing the
+// default text format.
+func Format(fn FormatterFn) Option {
+	return func(l *Middleware) {
+		l.formatFn = fn
+	}
+}
+
+// FormatText renders an Entry the way Middleware has always logged requests:
+// "METHOD - URL - IP - STATUS (SIZE) - DURATION[ - USER][ - SUBJECT][ - TRACE_ID][ - BODY]"
+func FormatText(e Entry) string {
+	var bld strings.Builder
+	bld.WriteString(fmt.Sprintf("%s - %s - %s - %d (%d) - %v", e.Method, e.URL, e.RemoteIP, e.Status, e.Size, e.Duration))
+
+	if e.User != "" {
+		bld.WriteString(" - ")
+		bld.WriteString(e.User)
+	}
+
+	if e.Subject != "" {
+		bld.WriteString(" - ")
+		bld.WriteString(e.Subject)
+	}
+
+	if e.TraceID != "" {
+		bld.WriteString(" - ")
+		bld.WriteString(e.TraceID)
+	}
+
+	if e.Body != "" {
+		bld.WriteString(" - ")
+		bld.WriteString(e.Body)
+	}
+
+	return bld.String()
+}
+
+// jsonEntry is the wire representation produced by FormatJSON.
+type jsonEntry struct {
+	Time       string  `json:"ts"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Query      string  `json:"query,omitempty"`
+	Status     int     `json:"status"`
+	Bytes      int     `json:"bytes"`
+	DurationMS float64 `json:"duration_ms"`
+	RemoteIP   string  `json:"remote_ip"`
+	User       string  `json:"user,omitempty"`
+	Subject    string  `json:"subject,omitempty"`
+	RequestID  string  `json:"request_id,omitempty"`
+	Body       string  `json:"body,omitempty"`
+	RespBody   string  `json:"response_body,omitempty"`
+}
+
+// FormatJSON renders an Entry as a single line of JSON, suitable for
+// ingestion by log pipelines such as ELK or Loki.
+func FormatJSON(e Entry) string {
+	path := e.URL
+	if i := strings.IndexByte(path, '?'); i != -1 {
+		path = path[:i]
+	}
+
+	je := jsonEntry{
+		Time:       e.Time.Format(time.RFC3339Nano),
+		Method:     e.Method,
+		Path:       path,
+		Query:      e.Query,
+		Status:     e.Status,
+		Bytes:      e.Size,
+		DurationMS: float64(e.Duration) / float64(time.Millisecond),
+		RemoteIP:   e.RemoteIP,
+		User:       e.User,
+		Subject:    e.Subject,
+		RequestID:  e.TraceID,
+		Body:       e.Body,
+		RespBody:   e.ResponseBody,
+	}
+
+	b, err := json.Marshal(je)
+	if err != nil {
+		return fmt.Sprintf(`{"error": "failed to marshal log entry: %v"}`, err)
+	}
+	return string(b)
+}
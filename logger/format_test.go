@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleEntry() Entry {
+	return Entry{
+		Time:         time.Date(2026, 7, 26, 10, 0, 0, 0, time.UTC),
+		Method:       "GET",
+		URL:          "/widgets?token=secret",
+		Query:        "token=........",
+		RemoteIP:     "1.2.3.4",
+		Status:       200,
+		Size:         42,
+		Duration:     150 * time.Millisecond,
+		User:         "alice",
+		Subject:      "widgets:read",
+		TraceID:      "abc-123",
+		Body:         "request body",
+		ResponseBody: "response body",
+	}
+}
+
+func TestFormatJSON_SplitsPathFromQuery(t *testing.T) {
+	line := FormatJSON(sampleEntry())
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(line), &got))
+
+	assert.Equal(t, "/widgets", got["path"])
+	assert.Equal(t, "token=........", got["query"])
+}
+
+func TestFormatJSON_FieldSet(t *testing.T) {
+	line := FormatJSON(sampleEntry())
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(line), &got))
+
+	assert.Equal(t, "GET", got["method"])
+	assert.Equal(t, float64(200), got["status"])
+	assert.Equal(t, float64(42), got["bytes"])
+	assert.Equal(t, float64(150), got["duration_ms"])
+	assert.Equal(t, "1.2.3.4", got["remote_ip"])
+	assert.Equal(t, "alice", got["user"])
+	assert.Equal(t, "widgets:read", got["subject"])
+	assert.Equal(t, "abc-123", got["request_id"])
+	assert.Equal(t, "request body", got["body"])
+	assert.Equal(t, "response body", got["response_body"])
+}
+
+func TestFormatJSON_OmitsEmptyOptionalFields(t *testing.T) {
+	e := sampleEntry()
+	e.User, e.Subject, e.TraceID, e.Body, e.ResponseBody, e.Query = "", "", "", "", "", ""
+	line := FormatJSON(e)
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(line), &got))
+
+	for _, key := range []string{"query", "user", "subject", "request_id", "body", "response_body"} {
+		_, present := got[key]
+		assert.False(t, present, "expected %q to be omitted", key)
+	}
+}
+
+func TestFormatText_Ordering(t *testing.T) {
+	e := sampleEntry()
+	line := FormatText(e)
+
+	want := "GET - /widgets?token=secret - 1.2.3.4 - 200 (42) - 150ms - alice - widgets:read - abc-123 - request body"
+	assert.Equal(t, want, line)
+}
+
+func TestFormatText_OmitsEmptyTrailingFields(t *testing.T) {
+	e := sampleEntry()
+	e.User, e.Subject, e.TraceID, e.Body = "", "", "", ""
+	line := FormatText(e)
+
+	want := "GET - /widgets?token=secret - 1.2.3.4 - 200 (42) - 150ms"
+	assert.Equal(t, want, line)
+}
@@ -1,30 +1,32 @@
 package logger
 
 import (
-	"bufio"
 	"bytes"
-	"fmt"
 	"io/ioutil"
 	"log"
-	"net"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/RupeshHacker/rest/requestid"
+	"github.com/RupeshHacker/rest/responsewriter"
 )
 
 var reMultWhtsp = regexp.MustCompile(`[\s\p{Zs}]{2,}`)
 
 // Middleware for logging rest requests
 type Middleware struct {
-	prefix      string
-	maxBodySize int
-	flags       []Flag
-	ipFn        func(ip string) string
-	userFn      func(r *http.Request) (string, error)
-	subjFn      func(r *http.Request) (string, error)
-	log         Backend
+	prefix       string
+	maxBodySize  int
+	flags        []Flag
+	ipFn         func(ip string) string
+	userFn       func(r *http.Request) (string, error)
+	subjFn       func(r *http.Request) (string, error)
+	formatFn     FormatterFn
+	bodyFilterFn func(contentType string, isRequest bool) bool
+	log          Backend
 }
 
 // Flag type
@@ -35,6 +37,7 @@ const (
 	All Flag = iota
 	User
 	Body
+	Response
 	None
 )
 
@@ -62,6 +65,7 @@ func New(options ...Option) *Middleware {
 		prefix:      "",
 		maxBodySize: 1024,
 		flags:       []Flag{All},
+		formatFn:    FormatText,
 		log:         stdBackend{},
 	}
 	for _, opt := range options {
@@ -70,6 +74,16 @@ func New(options ...Option) *Middleware {
 	return &res
 }
 
+// BodyFilter sets a predicate deciding whether a body for the given content
+// type should be captured and logged. isRequest is true for the request body
+// and false for the response body. Returning false skips logging that body,
+// e.g. to avoid dumping images or other binary payloads.
+func BodyFilter(fn func(contentType string, isRequest bool) bool) Option {
+	return func(l *Middleware) {
+		l.bodyFilterFn = fn
+	}
+}
+
 // Handler middleware prints http log
 func (l *Middleware) Handler(next http.Handler) http.Handler {
 
@@ -80,7 +94,7 @@ func (l *Middleware) Handler(next http.Handler) http.Handler {
 			return
 		}
 
-		ww := newCustomResponseWriter(w)
+		ww, stats := responsewriter.Wrap(w, l.maxBodySize, l.inLogFlags(Response))
 		body, user := l.getBodyAndUser(r)
 		t1 := time.Now()
 		defer func() {
@@ -102,37 +116,36 @@ func (l *Middleware) Handler(next http.Handler) http.Handler {
 				remoteIP = l.ipFn(remoteIP)
 			}
 
-			var bld strings.Builder
-			if l.prefix != "" {
-				bld.WriteString(l.prefix)
-				bld.WriteString(" ")
+			entry := Entry{
+				Time:     t2,
+				Method:   r.Method,
+				URL:      rawurl,
+				Query:    u.RawQuery,
+				RemoteIP: remoteIP,
+				Status:   stats.Status,
+				Size:     stats.Size,
+				Duration: t2.Sub(t1),
+				User:     user,
+				TraceID:  l.traceID(r),
+				Body:     body,
 			}
 
-			bld.WriteString(fmt.Sprintf("%s - %s - %s - %d (%d) - %v", r.Method, rawurl, remoteIP, ww.status, ww.size, t2.Sub(t1)))
-
-			if user != "" {
-				bld.WriteString(" - ")
-				bld.WriteString(user)
+			if l.inLogFlags(Response) && l.bodyAllowed(w.Header().Get("Content-Type"), false) {
+				entry.ResponseBody = l.normalizeBody(stats.Body())
 			}
 
 			if l.subjFn != nil {
 				if subj, err := l.subjFn(r); err == nil {
-					bld.WriteString(" - ")
-					bld.WriteString(subj)
+					entry.Subject = subj
 				}
 			}
 
-			if traceID := r.Header.Get("X-Request-ID"); traceID != "" {
-				bld.WriteString(" - ")
-				bld.WriteString(traceID)
-			}
-
-			if body != "" {
-				bld.WriteString(" - ")
-				bld.WriteString(body)
+			line := l.formatFn(entry)
+			if l.prefix != "" {
+				line = l.prefix + " " + line
 			}
 
-			l.log.Logf("%s", bld.String())
+			l.log.Logf("%s", line)
 		}()
 
 		next.ServeHTTP(ww, r)
@@ -146,19 +159,10 @@ func (l *Middleware) getBodyAndUser(r *http.Request) (body string, user string)
 		return "", ""
 	}
 
-	if l.inLogFlags(Body) {
+	if l.inLogFlags(Body) && l.bodyAllowed(r.Header.Get("Content-Type"), true) {
 		if content, err := ioutil.ReadAll(r.Body); err == nil {
-			body = string(content)
 			r.Body = ioutil.NopCloser(bytes.NewReader(content))
-
-			if len(body) > 0 {
-				body = strings.Replace(body, "\n", " ", -1)
-				body = reMultWhtsp.ReplaceAllString(body, " ")
-			}
-
-			if len(body) > l.maxBodySize {
-				body = body[:l.maxBodySize] + "..."
-			}
+			body = l.normalizeBody(string(content))
 		}
 	}
 
@@ -174,13 +178,51 @@ func (l *Middleware) getBodyAndUser(r *http.Request) (body string, user string)
 
 func (l *Middleware) inLogFlags(f Flag) bool {
 	for _, flg := range l.flags {
-		if (flg == All && f != None) || flg == f {
+		// Response must be requested explicitly: unlike request-side logging,
+		// capturing response bodies is expensive/sensitive enough that All
+		// shouldn't turn it on for every request.
+		if (flg == All && f != None && f != Response) || flg == f {
 			return true
 		}
 	}
 	return false
 }
 
+// bodyAllowed reports whether a body of the given content type should be
+// captured, consulting bodyFilterFn if one was set.
+func (l *Middleware) bodyAllowed(contentType string, isRequest bool) bool {
+	if l.bodyFilterFn == nil {
+		return true
+	}
+	return l.bodyFilterFn(contentType, isRequest)
+}
+
+// traceID prefers the ID set by the requestid middleware on the request
+// context, falling back to the X-Request-ID header for callers that set it
+// upstream without going through that middleware.
+func (l *Middleware) traceID(r *http.Request) string {
+	if id := requestid.GetRequestID(r); id != "" {
+		return id
+	}
+	return r.Header.Get("X-Request-ID")
+}
+
+// normalizeBody flattens a body to a single line and truncates it to maxBodySize.
+func (l *Middleware) normalizeBody(body string) string {
+	if len(body) == 0 {
+		return body
+	}
+
+	body = strings.Replace(body, "\n", " ", -1)
+	body = reMultWhtsp.ReplaceAllString(body, " ")
+
+	if len(body) > l.maxBodySize {
+		body = body[:l.maxBodySize] + "..."
+	}
+
+	return body
+}
+
 var keysToHide = []string{"password", "passwd", "secret", "credentials", "token"}
 
 // Hide query values for keysToHide. May change order of query params.
@@ -215,45 +257,3 @@ func (l *Middleware) sanitizeQuery(query string) string {
 
 	return v.Encode()
 }
-
-// customResponseWriter implements ResponseWriter and keeping status and size
-type customResponseWriter struct {
-	http.ResponseWriter
-	status int
-	size   int
-}
-
-func newCustomResponseWriter(w http.ResponseWriter) *customResponseWriter {
-	return &customResponseWriter{
-		ResponseWriter: w,
-		status:         200,
-	}
-}
-
-// WriteHeader implements ResponseWriter and saves status
-func (c *customResponseWriter) WriteHeader(status int) {
-	c.status = status
-	c.ResponseWriter.WriteHeader(status)
-}
-
-// WriteHeader implements ResponseWriter and tracking size
-func (c *customResponseWriter) Write(b []byte) (int, error) {
-	size, err := c.ResponseWriter.Write(b)
-	c.size += size
-	return size, err
-}
-
-// Flush implements ResponseWriter
-func (c *customResponseWriter) Flush() {
-	if f, ok := c.ResponseWriter.(http.Flusher); ok {
-		f.Flush()
-	}
-}
-
-// Hijack implements ResponseWriter
-func (c *customResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	if hj, ok := c.ResponseWriter.(http.Hijacker); ok {
-		return hj.Hijack()
-	}
-	return nil, nil, fmt.Errorf("ResponseWriter does not implement the Hijacker interface")
-}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMiddleware builds a Middleware directly (bypassing the Option
+// mechanism, which has no way to override flags) so these tests can turn on
+// exactly the flags under test. It formats with FormatJSON rather than the
+// default FormatText, since FormatText never renders ResponseBody.
+func newTestMiddleware(flags []Flag) (*Middleware, *[]string) {
+	var lines []string
+	m := &Middleware{
+		maxBodySize: 1024,
+		flags:       flags,
+		formatFn:    FormatJSON,
+		log:         logFn(func(format string, args ...interface{}) { lines = append(lines, args[0].(string)) }),
+	}
+	return m, &lines
+}
+
+type logFn func(format string, args ...interface{})
+
+func (f logFn) Logf(format string, args ...interface{}) { f(format, args...) }
+
+func TestMiddleware_ResponseBodyOnlyCapturedWhenFlagSet(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("hello world"))
+	})
+
+	t.Run("All does not capture response body", func(t *testing.T) {
+		m, lines := newTestMiddleware([]Flag{All})
+		req := httptest.NewRequest("GET", "/", http.NoBody)
+		m.Handler(handler).ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Len(t, *lines, 1)
+		assert.NotContains(t, (*lines)[0], "hello world")
+	})
+
+	t.Run("Response flag captures response body", func(t *testing.T) {
+		m, lines := newTestMiddleware([]Flag{All, Response})
+		req := httptest.NewRequest("GET", "/", http.NoBody)
+		m.Handler(handler).ServeHTTP(httptest.NewRecorder(), req)
+
+		require.Len(t, *lines, 1)
+		assert.Contains(t, (*lines)[0], "hello world")
+	})
+}
+
+func TestMiddleware_ResponseBodyBoundedByMaxBodySize(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("0123456789"))
+	})
+
+	m, lines := newTestMiddleware([]Flag{All, Response})
+	m.maxBodySize = 4
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+	m.Handler(handler).ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, *lines, 1)
+	assert.Contains(t, (*lines)[0], `"response_body":"0123"`)
+	assert.NotContains(t, (*lines)[0], "0123456789")
+}
+
+func TestMiddleware_NoneSkipsLogging(t *testing.T) {
+	called := false
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	m, lines := newTestMiddleware([]Flag{None})
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+	m.Handler(handler).ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.True(t, called)
+	assert.Empty(t, *lines)
+}
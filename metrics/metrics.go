@@ -0,0 +1,131 @@
+// Package metrics provides a middleware recording Prometheus metrics for
+// REST handlers.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/RupeshHacker/rest/responsewriter"
+)
+
+// defaultDurationBuckets matches what most REST handlers here respond
+// within: sub-second for the common case, with room for a slow outlier.
+var defaultDurationBuckets = []float64{0.1, 0.3, 1.2, 5}
+
+// Middleware records http_requests_total, http_request_duration_seconds,
+// http_response_size_bytes and http_requests_in_flight for every request.
+type Middleware struct {
+	pathFn          func(r *http.Request) string
+	durationBuckets []float64
+	registerer      prometheus.Registerer
+
+	requestsTotal    *prometheus.CounterVec
+	requestDuration  *prometheus.HistogramVec
+	responseSize     *prometheus.HistogramVec
+	requestsInFlight prometheus.Gauge
+}
+
+// Option configures a Middleware.
+type Option func(*Middleware)
+
+// PathFn sets the function resolving the "path" label for a request. Use it
+// to return a route pattern (e.g. a chi pattern like "/users/{id}") instead
+// of the raw URL, so templated routes don't blow up label cardinality. The
+// default uses r.URL.Path as-is.
+func PathFn(fn func(r *http.Request) string) Option {
+	return func(m *Middleware) {
+		m.pathFn = fn
+	}
+}
+
+// DurationBuckets overrides the histogram buckets used for
+// http_request_duration_seconds, replacing the default {0.1, 0.3, 1.2, 5}.
+func DurationBuckets(buckets []float64) Option {
+	return func(m *Middleware) {
+		m.durationBuckets = buckets
+	}
+}
+
+// Registerer sets the prometheus.Registerer metrics are registered with,
+// replacing prometheus.DefaultRegisterer.
+func Registerer(reg prometheus.Registerer) Option {
+	return func(m *Middleware) {
+		m.registerer = reg
+	}
+}
+
+// New makes a metrics Middleware with the given options and registers its
+// collectors.
+func New(options ...Option) *Middleware {
+	m := &Middleware{
+		pathFn:          func(r *http.Request) string { return r.URL.Path },
+		durationBuckets: defaultDurationBuckets,
+		registerer:      prometheus.DefaultRegisterer,
+	}
+	for _, opt := range options {
+		opt(m)
+	}
+
+	m.requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests processed.",
+	}, []string{"method", "path", "code"})
+
+	m.requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request duration in seconds.",
+		Buckets: m.durationBuckets,
+	}, []string{"method", "path", "code"})
+
+	m.responseSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_response_size_bytes",
+		Help:    "HTTP response size in bytes.",
+		Buckets: prometheus.ExponentialBuckets(100, 10, 6),
+	}, []string{"method", "path", "code"})
+
+	m.requestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests currently being served.",
+	})
+
+	m.registerer.MustRegister(m.requestsTotal, m.requestDuration, m.responseSize, m.requestsInFlight)
+
+	return m
+}
+
+// Handler middleware records per-request metrics, reusing the status/size
+// tracking shared with logger.Middleware.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		m.requestsInFlight.Inc()
+		defer m.requestsInFlight.Dec()
+
+		ww, stats := responsewriter.Wrap(w, 0, false)
+		t1 := time.Now()
+		next.ServeHTTP(ww, r)
+		dur := time.Since(t1).Seconds()
+
+		path := m.pathFn(r)
+		code := strconv.Itoa(stats.Status)
+
+		m.requestsTotal.WithLabelValues(r.Method, path, code).Inc()
+		m.requestDuration.WithLabelValues(r.Method, path, code).Observe(dur)
+		m.responseSize.WithLabelValues(r.Method, path, code).Observe(float64(stats.Size))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Handler returns an http.Handler serving the metrics registered against reg
+// in the Prometheus exposition format, for convenience mounting at
+// "/metrics". Pass nil to serve prometheus.DefaultGatherer.
+func Handler(reg *prometheus.Registry) http.Handler {
+	if reg == nil {
+		return promhttp.Handler()
+	}
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+}
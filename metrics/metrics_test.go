@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware_PathFn(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := New(Registerer(reg), PathFn(func(r *http.Request) string { return "/users/{id}" }))
+
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/users/42", http.NoBody)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.requestsTotal.WithLabelValues("GET", "/users/{id}", "200")))
+}
+
+func TestMiddleware_RecordsStatusAndSize(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := New(Registerer(reg))
+
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	}))
+
+	req := httptest.NewRequest("POST", "/widgets", http.NoBody)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.requestsTotal.WithLabelValues("POST", "/widgets", "201")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.responseSize))
+}
+
+func TestMiddleware_DefaultDurationBuckets(t *testing.T) {
+	m := New(Registerer(prometheus.NewRegistry()))
+	assert.Equal(t, []float64{0.1, 0.3, 1.2, 5}, m.durationBuckets)
+}
+
+func TestMiddleware_CustomDurationBuckets(t *testing.T) {
+	custom := []float64{0.5, 1, 2}
+	m := New(Registerer(prometheus.NewRegistry()), DurationBuckets(custom))
+	assert.Equal(t, custom, m.durationBuckets)
+}
+
+func TestMiddleware_InFlightGaugeTracksPanics(t *testing.T) {
+	m := New(Registerer(prometheus.NewRegistry()))
+
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.requestsInFlight))
+		panic("boom")
+	}))
+
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+
+	func() {
+		defer func() { _ = recover() }()
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.requestsInFlight))
+}
+
+func TestMiddleware_InFlightGaugeIncDec(t *testing.T) {
+	m := New(Registerer(prometheus.NewRegistry()))
+	release := make(chan struct{})
+
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	done := make(chan struct{})
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(m.requestsInFlight) == 1
+	}, time.Second, time.Millisecond)
+
+	close(release)
+	<-done
+
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.requestsInFlight))
+}
@@ -0,0 +1,185 @@
+// Package realip resolves the real client IP of an incoming request,
+// honoring X-Forwarded-For, X-Real-IP and Forwarded headers only when they
+// come from a trusted proxy.
+package realip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Resolver extracts the real client IP from a request. Forwarded headers are
+// only trusted when the immediate peer (r.RemoteAddr) is one of the
+// Resolver's trusted CIDRs - otherwise anyone talking to the service
+// directly could spoof them.
+type Resolver struct {
+	trusted []*net.IPNet
+}
+
+// defaultTrustedCIDRs covers RFC1918 private ranges and loopback, the usual
+// shape of an internal load balancer or reverse proxy hop.
+var defaultTrustedCIDRs = []string{
+	"127.0.0.0/8",
+	"::1/128",
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+}
+
+// NewResolver makes a Resolver trusting the given CIDRs as proxies.
+func NewResolver(trustedCIDRs ...string) (*Resolver, error) {
+	res := &Resolver{}
+	for _, c := range trustedCIDRs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted CIDR %q: %w", c, err)
+		}
+		res.trusted = append(res.trusted, n)
+	}
+	return res, nil
+}
+
+var defaultResolver = mustDefaultResolver()
+
+func mustDefaultResolver() *Resolver {
+	r, err := NewResolver(defaultTrustedCIDRs...)
+	if err != nil {
+		panic("realip: bad default trusted CIDRs: " + err.Error())
+	}
+	return r
+}
+
+// Get returns the real client IP for r using a default Resolver trusting
+// RFC1918 private ranges and loopback.
+func Get(r *http.Request) (string, error) {
+	return defaultResolver.Get(r)
+}
+
+// Get returns the real client IP for r, preferring X-Forwarded-For, then
+// X-Real-IP, then the Forwarded header, but only consulting any of them when
+// the immediate peer is trusted. An unknown or unparseable peer is treated
+// as untrusted, not as a free pass: trusting the headers precisely when the
+// peer can't be identified would let anyone spoof them. It falls back to
+// the host part of r.RemoteAddr (without the port) when the peer is known
+// but not trusted, or when none of the headers yield an address.
+func (rs *Resolver) Get(r *http.Request) (string, error) {
+	peer, peerKnown := hostOnly(r.RemoteAddr)
+
+	if peerKnown && rs.isTrusted(peer) {
+		if ip, ok := rs.fromXForwardedFor(r.Header.Get("X-Forwarded-For")); ok {
+			return ip, nil
+		}
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+			return ip, nil
+		}
+		if ip, ok := rs.fromForwarded(r.Header.Get("Forwarded")); ok {
+			return ip, nil
+		}
+	}
+
+	if peerKnown {
+		return peer, nil
+	}
+
+	return "", errors.New("can't get IP from request")
+}
+
+// fromXForwardedFor walks X-Forwarded-For right-to-left (entries are
+// prepended by each hop, so the rightmost one is closest to us) and returns
+// the first address that isn't itself a trusted proxy.
+func (rs *Resolver) fromXForwardedFor(header string) (string, bool) {
+	parts := strings.Split(header, ",")
+	for i := len(parts) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(parts[i])
+		if ip == "" || rs.isTrusted(ip) {
+			continue
+		}
+		return ip, true
+	}
+	return "", false
+}
+
+// fromForwarded parses the RFC 7239 Forwarded header, walking hops
+// right-to-left the same way fromXForwardedFor does over X-Forwarded-For.
+func (rs *Resolver) fromForwarded(header string) (string, bool) {
+	hops := strings.Split(header, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		forVal := forwardedForToken(hops[i])
+		if forVal == "" {
+			continue
+		}
+		ip := stripForwardedFor(forVal)
+		if ip == "" || (net.ParseIP(ip) != nil && rs.isTrusted(ip)) {
+			continue
+		}
+		return ip, true
+	}
+	return "", false
+}
+
+// forwardedForToken extracts the for= token's value from a single Forwarded
+// header hop, e.g. `for=192.0.2.60;proto=http;by=203.0.113.43`.
+func forwardedForToken(hop string) string {
+	for _, kv := range strings.Split(hop, ";") {
+		parts := strings.SplitN(strings.TrimSpace(kv), "=", 2)
+		if len(parts) != 2 || !strings.EqualFold(strings.TrimSpace(parts[0]), "for") {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(parts[1]), `"`)
+	}
+	return ""
+}
+
+// stripForwardedFor strips the optional port from a for= value, unwrapping
+// IPv6 addresses from their brackets. Obfuscated identifiers (e.g.
+// "_hidden") and "unknown" pass through unchanged.
+func stripForwardedFor(v string) string {
+	if strings.HasPrefix(v, "[") {
+		if end := strings.Index(v, "]"); end != -1 {
+			return v[1:end]
+		}
+		return v
+	}
+
+	if host, _, err := net.SplitHostPort(v); err == nil {
+		return host
+	}
+
+	return v
+}
+
+// isTrusted reports whether ip belongs to one of the resolver's trusted CIDRs.
+func (rs *Resolver) isTrusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range rs.trusted {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostOnly splits the host part out of a RemoteAddr-shaped "host:port"
+// string, reporting false if no usable IP could be determined.
+func hostOnly(remoteAddr string) (string, bool) {
+	if remoteAddr == "" {
+		return "", false
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	host = strings.Trim(host, "[]")
+
+	if net.ParseIP(host) == nil {
+		return "", false
+	}
+	return host, true
+}
@@ -11,10 +11,16 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// trustedRemoteAddr is a loopback address, trusted by the default Resolver,
+// used so these tests exercise header resolution rather than the
+// peer-trust guard itself.
+const trustedRemoteAddr = "127.0.0.1:12345"
+
 func TestGetFromHeaders(t *testing.T) {
 	{
 		req, err := http.NewRequest("GET", "/something", http.NoBody)
 		assert.NoError(t, err)
+		req.RemoteAddr = trustedRemoteAddr
 		req.Header.Add("Something", "1234567")
 		req.Header.Add("X-Real-IP", "8.8.8.8")
 		adr, err := Get(req)
@@ -24,6 +30,7 @@ func TestGetFromHeaders(t *testing.T) {
 	{
 		req, err := http.NewRequest("GET", "/something", http.NoBody)
 		assert.NoError(t, err)
+		req.RemoteAddr = trustedRemoteAddr
 		req.Header.Add("Something", "1234567")
 		req.Header.Add("X-Forwarded-For", "8.8.8.8,1.1.1.2, 30.30.30.1")
 		adr, err := Get(req)
@@ -33,6 +40,7 @@ func TestGetFromHeaders(t *testing.T) {
 	{
 		req, err := http.NewRequest("GET", "/something", http.NoBody)
 		assert.NoError(t, err)
+		req.RemoteAddr = trustedRemoteAddr
 		req.Header.Add("Something", "1234567")
 		req.Header.Add("X-Forwarded-For", "8.8.8.8,1.1.1.2,192.168.1.1,10.0.0.65")
 		adr, err := Get(req)
@@ -42,6 +50,7 @@ func TestGetFromHeaders(t *testing.T) {
 	{
 		req, err := http.NewRequest("GET", "/something", http.NoBody)
 		assert.NoError(t, err)
+		req.RemoteAddr = trustedRemoteAddr
 		req.Header.Add("Something", "1234567")
 		req.Header.Add("X-Forwarded-For", "30.30.30.1")
 		req.Header.Add("X-Real-Ip", "10.0.0.1")
@@ -52,6 +61,7 @@ func TestGetFromHeaders(t *testing.T) {
 	{
 		req, err := http.NewRequest("GET", "/something", http.NoBody)
 		assert.NoError(t, err)
+		req.RemoteAddr = trustedRemoteAddr
 		req.Header.Add("Something", "1234567")
 		req.Header.Add("X-Forwarded-For", "30.30.30.1")
 		req.Header.Add("X-Real-Ip", "8.8.8.8")
@@ -62,6 +72,7 @@ func TestGetFromHeaders(t *testing.T) {
 	{
 		req, err := http.NewRequest("GET", "/something", http.NoBody)
 		assert.NoError(t, err)
+		req.RemoteAddr = trustedRemoteAddr
 		req.Header.Add("Something", "1234567")
 		req.Header.Add("X-Forwarded-For", "10.0.0.2,192.168.1.1")
 		req.Header.Add("X-Real-Ip", "8.8.8.8")
@@ -78,6 +89,19 @@ func TestGetFromHeaders(t *testing.T) {
 	}
 }
 
+func TestGetFromHeadersIgnoredWhenPeerUntrusted(t *testing.T) {
+	req, err := http.NewRequest("GET", "/something", http.NoBody)
+	require.NoError(t, err)
+	req.RemoteAddr = "203.0.113.9:12345"
+	req.Header.Add("X-Forwarded-For", "8.8.8.8")
+	req.Header.Add("X-Real-IP", "8.8.8.8")
+	req.Header.Add("Forwarded", "for=8.8.8.8")
+
+	adr, err := Get(req)
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.9", adr)
+}
+
 func TestGetFromRemoteAddr(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("%v", r)
@@ -91,4 +115,4 @@ func TestGetFromRemoteAddr(t *testing.T) {
 	client := http.Client{Timeout: time.Second}
 	_, err = client.Do(req)
 	require.NoError(t, err)
-}
\ No newline at end of file
+}
@@ -0,0 +1,125 @@
+package realip
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewResolver(t *testing.T) {
+	{
+		r, err := NewResolver("10.0.0.0/8", "192.168.0.0/16")
+		require.NoError(t, err)
+		assert.Len(t, r.trusted, 2)
+	}
+	{
+		r, err := NewResolver("not-a-cidr")
+		assert.Error(t, err)
+		assert.Nil(t, r)
+	}
+}
+
+func TestResolver_IsTrusted(t *testing.T) {
+	r, err := NewResolver("10.0.0.0/8", "2001:db8::/32")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		ip      string
+		trusted bool
+	}{
+		{"in first CIDR", "10.1.2.3", true},
+		{"in second CIDR", "2001:db8::1", true},
+		{"outside any CIDR", "8.8.8.8", false},
+		{"not an IP", "not-an-ip", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.trusted, r.isTrusted(tc.ip))
+		})
+	}
+}
+
+func TestResolver_GetTrustsOnlyKnownProxies(t *testing.T) {
+	r, err := NewResolver("10.0.0.0/8")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		header     string
+		headerName string
+		want       string
+	}{
+		{"trusted peer, X-Forwarded-For honored", "10.0.0.5:1234", "8.8.8.8", "X-Forwarded-For", "8.8.8.8"},
+		{"untrusted peer, X-Forwarded-For ignored", "203.0.113.9:1234", "8.8.8.8", "X-Forwarded-For", "203.0.113.9"},
+		{"trusted peer, X-Real-IP honored", "10.0.0.5:1234", "8.8.8.8", "X-Real-IP", "8.8.8.8"},
+		{"untrusted peer, X-Real-IP ignored", "203.0.113.9:1234", "8.8.8.8", "X-Real-IP", "203.0.113.9"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/", http.NoBody)
+			require.NoError(t, err)
+			req.RemoteAddr = tc.remoteAddr
+			req.Header.Set(tc.headerName, tc.header)
+
+			ip, err := r.Get(req)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, ip)
+		})
+	}
+}
+
+func TestResolver_GetFromForwarded(t *testing.T) {
+	r, err := NewResolver("10.0.0.0/8")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{
+			name:       "trusted peer, plain for=",
+			remoteAddr: "10.0.0.5:1234",
+			forwarded:  "for=192.0.2.60;proto=http;by=203.0.113.43",
+			want:       "192.0.2.60",
+		},
+		{
+			name:       "trusted peer, quoted IPv6 for=",
+			remoteAddr: "10.0.0.5:1234",
+			forwarded:  `for="[2001:db8:cafe::17]:4711"`,
+			want:       "2001:db8:cafe::17",
+		},
+		{
+			name:       "trusted peer, multiple hops picks closest non-trusted",
+			remoteAddr: "10.0.0.5:1234",
+			forwarded:  "for=192.0.2.60, for=10.0.0.9",
+			want:       "192.0.2.60",
+		},
+		{
+			name:       "untrusted peer, Forwarded ignored",
+			remoteAddr: "203.0.113.9:1234",
+			forwarded:  "for=192.0.2.60",
+			want:       "203.0.113.9",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/", http.NoBody)
+			require.NoError(t, err)
+			req.RemoteAddr = tc.remoteAddr
+			req.Header.Set("Forwarded", tc.forwarded)
+
+			ip, err := r.Get(req)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, ip)
+		})
+	}
+}
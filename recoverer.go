@@ -0,0 +1,97 @@
+package rest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/RupeshHacker/rest/logger"
+)
+
+// recovererConfig holds Recoverer's options.
+type recovererConfig struct {
+	log     logger.Backend
+	json    bool
+	onPanic func(r *http.Request, v interface{}, stack []byte)
+}
+
+// RecovererOpt configures Recoverer.
+type RecovererOpt func(*recovererConfig)
+
+// RecovererLog sets the backend Recoverer logs recovered panics and their
+// stack trace to, the same logger.Backend interface logger.Middleware uses,
+// so both can be routed to logrus/zerolog/etc. Defaults to the standard
+// library log package.
+func RecovererLog(l logger.Backend) RecovererOpt {
+	return func(c *recovererConfig) {
+		c.log = l
+	}
+}
+
+// RecovererJSON makes Recoverer respond with a JSON body instead of the
+// default plain text one.
+func RecovererJSON() RecovererOpt {
+	return func(c *recovererConfig) {
+		c.json = true
+	}
+}
+
+// RecovererOnPanic sets a hook invoked with the recovered value and stack
+// trace, e.g. to forward panics to Sentry or a similar crash reporter.
+func RecovererOnPanic(fn func(r *http.Request, v interface{}, stack []byte)) RecovererOpt {
+	return func(c *recovererConfig) {
+		c.onPanic = fn
+	}
+}
+
+type stdLogBackend struct{}
+
+func (stdLogBackend) Logf(format string, args ...interface{}) { log.Printf(format, args...) }
+
+// Recoverer middleware recovers from panics in the handlers below it, logs
+// the panic value and stack trace, and responds with a 500 instead of
+// letting the panic unwind and kill the request. Placed below
+// logger.Middleware in the chain, the recovered request still produces a
+// log line with status 500, since the 500 is written through the same
+// response writer logger tracks.
+func Recoverer(opts ...RecovererOpt) func(http.Handler) http.Handler {
+	cfg := recovererConfig{log: stdLogBackend{}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+
+				stack := debug.Stack()
+				cfg.log.Logf("[ERROR] panic: %v, method=%s, url=%s\n%s", v, r.Method, r.URL.String(), stack)
+
+				if cfg.onPanic != nil {
+					cfg.onPanic(r, v, stack)
+				}
+
+				writeRecoveryResponse(w, cfg.json)
+			}()
+
+			next.ServeHTTP(w, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+func writeRecoveryResponse(w http.ResponseWriter, asJSON bool) {
+	if !asJSON {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": http.StatusText(http.StatusInternalServerError)})
+}
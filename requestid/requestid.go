@@ -0,0 +1,117 @@
+// Package requestid propagates or generates a correlation ID per request and
+// makes it available to downstream handlers and middlewares.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type contextKey string
+
+const ctxKeyRequestID contextKey = "requestID"
+
+// defaultHeader is the header requestid reads an inbound ID from and echoes
+// the resolved ID on.
+const defaultHeader = "X-Request-ID"
+
+// Middleware generates or propagates a request ID for every request.
+type Middleware struct {
+	header string
+}
+
+// Option configures a Middleware.
+type Option func(*Middleware)
+
+// Header sets the header name used to read and echo the request ID,
+// overriding the default "X-Request-ID".
+func Header(name string) Option {
+	return func(m *Middleware) {
+		m.header = name
+	}
+}
+
+// New makes a requestid Middleware with the given options.
+func New(options ...Option) *Middleware {
+	res := &Middleware{header: defaultHeader}
+	for _, opt := range options {
+		opt(res)
+	}
+	return res
+}
+
+// Handler returns the default requestid middleware, using the
+// "X-Request-ID" header.
+func Handler(next http.Handler) http.Handler {
+	return New().Handler(next)
+}
+
+// Handler middleware resolves the request ID, stores it on the request
+// context and echoes it on the response.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		id := m.resolve(r)
+		w.Header().Set(m.header, id)
+		next.ServeHTTP(w, SetRequestID(r, id))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// resolve returns the inbound request ID, preferring (in order) the
+// configured header, a W3C traceparent header already set by a
+// tracing-enabled mesh, and finally a freshly generated ID.
+func (m *Middleware) resolve(r *http.Request) string {
+	if id := r.Header.Get(m.header); id != "" {
+		return id
+	}
+
+	if id, ok := traceIDFromTraceparent(r.Header.Get("Traceparent")); ok {
+		return id
+	}
+
+	return newID()
+}
+
+// SetRequestID sets id on the request's context.
+func SetRequestID(r *http.Request, id string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), ctxKeyRequestID, id))
+}
+
+// GetRequestID returns the request ID stored on r's context, or "" if none
+// was set.
+func GetRequestID(r *http.Request) string {
+	id, _ := r.Context().Value(ctxKeyRequestID).(string)
+	return id
+}
+
+// traceIDFromTraceparent extracts the trace-id field from a W3C traceparent
+// header (version-traceid-parentid-flags, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01") so that services
+// behind a tracing-enabled mesh reuse the existing trace rather than
+// generating an unrelated ID.
+func traceIDFromTraceparent(tp string) (string, bool) {
+	parts := strings.Split(tp, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// newID generates a random UUID v4 string.
+func newID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%016x", uintptr(len(b))) // practically unreachable, crypto/rand doesn't fail on supported platforms
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,124 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceIDFromTraceparent(t *testing.T) {
+	cases := []struct {
+		name string
+		tp   string
+		want string
+		ok   bool
+	}{
+		{"valid", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"empty", "", "", false},
+		{"too few fields", "00-4bf92f3577b34da6a3ce929d0e0e4736", "", false},
+		{"trace-id too short", "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", "", false},
+		{"trace-id non-hex", "00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			id, ok := traceIDFromTraceparent(tc.tp)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.want, id)
+		})
+	}
+}
+
+var uuidV4Re = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewID(t *testing.T) {
+	id := newID()
+	assert.Regexp(t, uuidV4Re, id, "newID must produce a version-4, variant-1 UUID")
+	assert.NotEqual(t, id, newID(), "two calls must not collide")
+}
+
+func TestMiddleware_HandlerEchoesInboundID(t *testing.T) {
+	m := New()
+	var gotCtxID string
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCtxID = GetRequestID(r)
+	}))
+
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+	req.Header.Set("X-Request-ID", "inbound-id")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, "inbound-id", gotCtxID)
+	assert.Equal(t, "inbound-id", rec.Header().Get("X-Request-ID"))
+}
+
+func TestMiddleware_HandlerFallsBackToTraceparent(t *testing.T) {
+	m := New()
+	var gotCtxID string
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCtxID = GetRequestID(r)
+	}))
+
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", gotCtxID)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", rec.Header().Get("X-Request-ID"))
+}
+
+func TestMiddleware_HandlerGeneratesIDWhenAbsent(t *testing.T) {
+	m := New()
+	var gotCtxID string
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCtxID = GetRequestID(r)
+	}))
+
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Regexp(t, uuidV4Re, gotCtxID)
+	assert.Equal(t, gotCtxID, rec.Header().Get("X-Request-ID"))
+}
+
+func TestMiddleware_HeaderOption(t *testing.T) {
+	m := New(Header("X-Trace-ID"))
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+	req.Header.Set("X-Trace-ID", "custom-id")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, "custom-id", rec.Header().Get("X-Trace-ID"))
+	assert.Empty(t, rec.Header().Get("X-Request-ID"))
+}
+
+func TestSetGetRequestID(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+	assert.Equal(t, "", GetRequestID(req))
+
+	req = SetRequestID(req, "abc-123")
+	assert.Equal(t, "abc-123", GetRequestID(req))
+}
+
+func TestHandlerConvenienceFunc(t *testing.T) {
+	var gotCtxID string
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCtxID = GetRequestID(r)
+	}))
+
+	req := httptest.NewRequest("GET", "/", http.NoBody)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	require.NotEmpty(t, gotCtxID)
+	assert.Equal(t, gotCtxID, rec.Header().Get("X-Request-ID"))
+}
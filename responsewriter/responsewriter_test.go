@@ -0,0 +1,231 @@
+package responsewriter
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBase is a minimal http.ResponseWriter delegate used to assemble test
+// doubles implementing every combination of Flusher/Hijacker/Pusher/
+// io.ReaderFrom, the same way Wrap's own wrapWriter* types do for the real
+// thing.
+type fakeBase struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (f *fakeBase) Header() http.Header {
+	if f.header == nil {
+		f.header = http.Header{}
+	}
+	return f.header
+}
+
+func (f *fakeBase) Write(b []byte) (int, error) { return f.body.Write(b) }
+func (f *fakeBase) WriteHeader(status int)      { f.status = status }
+
+type fakeF struct{ *fakeBase }
+
+func (fakeF) Flush() {}
+
+type fakeH struct{ *fakeBase }
+
+func (fakeH) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+
+type fakeP struct{ *fakeBase }
+
+func (fakeP) Push(string, *http.PushOptions) error { return nil }
+
+type fakeR struct{ *fakeBase }
+
+func (f fakeR) ReadFrom(r io.Reader) (int64, error) { return io.Copy(&f.body, r) }
+
+type fakeFH struct{ *fakeBase }
+
+func (fakeFH) Flush()                                       {}
+func (fakeFH) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+
+type fakeFP struct{ *fakeBase }
+
+func (fakeFP) Flush()                               {}
+func (fakeFP) Push(string, *http.PushOptions) error { return nil }
+
+type fakeFR struct{ *fakeBase }
+
+func (fakeFR) Flush()                                {}
+func (f fakeFR) ReadFrom(r io.Reader) (int64, error) { return io.Copy(&f.body, r) }
+
+type fakeHP struct{ *fakeBase }
+
+func (fakeHP) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+func (fakeHP) Push(string, *http.PushOptions) error         { return nil }
+
+type fakeHR struct{ *fakeBase }
+
+func (fakeHR) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+func (f fakeHR) ReadFrom(r io.Reader) (int64, error)        { return io.Copy(&f.body, r) }
+
+type fakePR struct{ *fakeBase }
+
+func (fakePR) Push(string, *http.PushOptions) error  { return nil }
+func (f fakePR) ReadFrom(r io.Reader) (int64, error) { return io.Copy(&f.body, r) }
+
+type fakeFHP struct{ *fakeBase }
+
+func (fakeFHP) Flush()                                       {}
+func (fakeFHP) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+func (fakeFHP) Push(string, *http.PushOptions) error         { return nil }
+
+type fakeFHR struct{ *fakeBase }
+
+func (fakeFHR) Flush()                                       {}
+func (fakeFHR) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+func (f fakeFHR) ReadFrom(r io.Reader) (int64, error)        { return io.Copy(&f.body, r) }
+
+type fakeFPR struct{ *fakeBase }
+
+func (fakeFPR) Flush()                                {}
+func (fakeFPR) Push(string, *http.PushOptions) error  { return nil }
+func (f fakeFPR) ReadFrom(r io.Reader) (int64, error) { return io.Copy(&f.body, r) }
+
+type fakeHPR struct{ *fakeBase }
+
+func (fakeHPR) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+func (fakeHPR) Push(string, *http.PushOptions) error         { return nil }
+func (f fakeHPR) ReadFrom(r io.Reader) (int64, error)        { return io.Copy(&f.body, r) }
+
+type fakeFHPR struct{ *fakeBase }
+
+func (fakeFHPR) Flush()                                       {}
+func (fakeFHPR) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+func (fakeFHPR) Push(string, *http.PushOptions) error         { return nil }
+func (f fakeFHPR) ReadFrom(r io.Reader) (int64, error)        { return io.Copy(&f.body, r) }
+
+// newFakeDelegate returns an http.ResponseWriter implementing exactly the
+// requested combination of optional interfaces, mirroring how Wrap itself
+// picks a wrapWriter* type.
+func newFakeDelegate(flush, hijack, push, readerFrom bool) (http.ResponseWriter, *fakeBase) {
+	base := &fakeBase{}
+	switch {
+	case flush && hijack && push && readerFrom:
+		return fakeFHPR{base}, base
+	case flush && hijack && push:
+		return fakeFHP{base}, base
+	case flush && hijack && readerFrom:
+		return fakeFHR{base}, base
+	case flush && push && readerFrom:
+		return fakeFPR{base}, base
+	case hijack && push && readerFrom:
+		return fakeHPR{base}, base
+	case flush && hijack:
+		return fakeFH{base}, base
+	case flush && push:
+		return fakeFP{base}, base
+	case flush && readerFrom:
+		return fakeFR{base}, base
+	case hijack && push:
+		return fakeHP{base}, base
+	case hijack && readerFrom:
+		return fakeHR{base}, base
+	case push && readerFrom:
+		return fakePR{base}, base
+	case flush:
+		return fakeF{base}, base
+	case hijack:
+		return fakeH{base}, base
+	case push:
+		return fakeP{base}, base
+	case readerFrom:
+		return fakeR{base}, base
+	default:
+		return base, base
+	}
+}
+
+func TestWrap_PreservesDelegateInterfaces(t *testing.T) {
+	cases := []struct {
+		name                            string
+		flush, hijack, push, readerFrom bool
+	}{
+		{"none", false, false, false, false},
+		{"flusher", true, false, false, false},
+		{"hijacker", false, true, false, false},
+		{"pusher", false, false, true, false},
+		{"readerFrom", false, false, false, true},
+		{"flusher+hijacker", true, true, false, false},
+		{"flusher+pusher", true, false, true, false},
+		{"flusher+readerFrom", true, false, false, true},
+		{"hijacker+pusher", false, true, true, false},
+		{"hijacker+readerFrom", false, true, false, true},
+		{"pusher+readerFrom", false, false, true, true},
+		{"flusher+hijacker+pusher", true, true, true, false},
+		{"flusher+hijacker+readerFrom", true, true, false, true},
+		{"flusher+pusher+readerFrom", true, false, true, true},
+		{"hijacker+pusher+readerFrom", false, true, true, true},
+		{"all", true, true, true, true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			delegate, _ := newFakeDelegate(tc.flush, tc.hijack, tc.push, tc.readerFrom)
+			wrapped, stats := Wrap(delegate, 1024, false)
+
+			_, gotFlusher := wrapped.(http.Flusher)
+			_, gotHijacker := wrapped.(http.Hijacker)
+			_, gotPusher := wrapped.(http.Pusher)
+			_, gotReaderFrom := wrapped.(io.ReaderFrom)
+
+			assert.Equal(t, tc.flush, gotFlusher, "Flusher")
+			assert.Equal(t, tc.hijack, gotHijacker, "Hijacker")
+			assert.Equal(t, tc.push, gotPusher, "Pusher")
+			assert.Equal(t, tc.readerFrom, gotReaderFrom, "ReaderFrom")
+
+			wrapped.WriteHeader(201)
+			n, err := wrapped.Write([]byte("hello"))
+			require.NoError(t, err)
+			assert.Equal(t, 5, n)
+			assert.Equal(t, 201, stats.Status)
+			assert.Equal(t, 5, stats.Size)
+
+			if hj, ok := wrapped.(http.Hijacker); ok {
+				_, _, err := hj.Hijack()
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWrap_HijackerNotAdvertisedWhenUnsupported(t *testing.T) {
+	delegate, _ := newFakeDelegate(false, false, false, false)
+	wrapped, _ := Wrap(delegate, 0, false)
+
+	_, ok := wrapped.(http.Hijacker)
+	assert.False(t, ok, "wrapper must not advertise Hijacker when the delegate doesn't support it")
+}
+
+func TestWrap_CapturesBoundedResponseBody(t *testing.T) {
+	delegate, _ := newFakeDelegate(false, false, false, false)
+	wrapped, stats := Wrap(delegate, 5, true)
+
+	_, err := wrapped.Write([]byte("hello world"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", stats.Body())
+}
+
+func TestWrap_BodyNotCapturedWhenDisabled(t *testing.T) {
+	delegate, _ := newFakeDelegate(false, false, false, false)
+	wrapped, stats := Wrap(delegate, 1024, false)
+
+	_, err := wrapped.Write([]byte("hello world"))
+	require.NoError(t, err)
+	assert.Equal(t, "", stats.Body())
+}